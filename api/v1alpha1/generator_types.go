@@ -0,0 +1,176 @@
+/*
+Copyright 2022 Dan Molik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Source configures one upstream cluster API a Generator discovers clusters
+// from, via pkg/sources.ClusterSource.
+type Source struct {
+	// Type selects the ClusterSource implementation, e.g. "capi", "hive",
+	// "ocm", or "secret".
+	Type string `json:"type"`
+
+	// Selector restricts which objects of Type this source discovers.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// ArgocdSpec configures which Argo CD instance a Generator registers
+// clusters with.
+type ArgocdSpec struct {
+	// Namespace is the namespace Argo CD runs in, and where cluster secrets
+	// and AppProjects are read from and written to. Defaults to "argocd".
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// GeneratorSpec defines the desired state of Generator
+type GeneratorSpec struct {
+	// Sources lists the upstream cluster APIs this Generator discovers
+	// clusters from.
+	// +optional
+	Sources []Source `json:"sources,omitempty"`
+
+	// AppProjectNames lists the Argo CD AppProjects whose destinations this
+	// Generator keeps in sync with the clusters it discovers.
+	// +optional
+	AppProjectNames []string `json:"appProjectNames,omitempty"`
+
+	// Argocd configures which Argo CD instance clusters are registered with.
+	// +optional
+	Argocd *ArgocdSpec `json:"argocd,omitempty"`
+
+	// ClusterNameTemplate is a Go template evaluated over the discovered
+	// cluster to produce the name it's registered under in Argo CD. Falls
+	// back to the kubeconfig's own cluster name when unset.
+	// +optional
+	ClusterNameTemplate string `json:"clusterNameTemplate,omitempty"`
+
+	// ServerOverride, if set, replaces the server URL read from each
+	// discovered cluster's kubeconfig.
+	// +optional
+	ServerOverride string `json:"serverOverride,omitempty"`
+}
+
+// ProjectStatus records the outcome of syncing one AppProject named in
+// Spec.AppProjectNames.
+type ProjectStatus struct {
+	// ProjectName is the AppProject this status describes.
+	ProjectName string `json:"projectName"`
+
+	// ObservedGeneration is the Generator generation this status reflects.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// DestinationNames lists the cluster names this Generator currently
+	// manages the destinations for on ProjectName, so a future sync can tell
+	// its own entries apart from ones added by another Generator or an
+	// operator.
+	// +optional
+	DestinationNames []string `json:"destinationNames,omitempty"`
+
+	// Conditions represent the latest available observations of the
+	// project's sync state.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// ClusterStatus records the outcome of the most recent attempt to register
+// one discovered cluster.
+type ClusterStatus struct {
+	// Name is the cluster name it was registered under in Argo CD.
+	Name string `json:"name"`
+
+	// Phase is the outcome of the most recent sync attempt, one of
+	// "Registered", "Deleted", or "Error".
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// LastSyncTime is when this cluster was last reconciled.
+	// +optional
+	LastSyncTime metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// Hash is the checksum of the kubeconfig most recently read for this
+	// cluster; the same value is stored on its Argo CD cluster secret so an
+	// unchanged kubeconfig doesn't re-issue an update.
+	// +optional
+	Hash string `json:"hash,omitempty"`
+
+	// Error is the error message from the most recent sync attempt, if
+	// Phase is "Error".
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// GeneratorStatus defines the observed state of Generator
+type GeneratorStatus struct {
+	// ObservedGeneration is the Generator generation last reconciled.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ClusterCount is the number of clusters discovered in the most recent
+	// reconcile.
+	// +optional
+	ClusterCount int `json:"clusterCount,omitempty"`
+
+	// RegisteredCount is the number of clusters currently registered in
+	// Argo CD.
+	// +optional
+	RegisteredCount int `json:"registeredCount,omitempty"`
+
+	// Clusters records the outcome of the most recent sync attempt for each
+	// discovered cluster.
+	// +optional
+	Clusters []ClusterStatus `json:"clusters,omitempty"`
+
+	// Projects records the outcome of syncing each AppProject named in
+	// Spec.AppProjectNames.
+	// +optional
+	Projects []ProjectStatus `json:"projects,omitempty"`
+
+	// Conditions represent the latest available observations of the
+	// Generator's state.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// Generator is the Schema for the generators API
+type Generator struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GeneratorSpec   `json:"spec,omitempty"`
+	Status GeneratorStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GeneratorList contains a list of Generator
+type GeneratorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Generator `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Generator{}, &GeneratorList{})
+}
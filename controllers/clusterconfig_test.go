@@ -0,0 +1,119 @@
+/*
+Copyright 2022 Dan Molik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestClusterConfigForPrefersClientCertOverTokenAndExec(t *testing.T) {
+	kubeconfig := &clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{
+			"test": {Server: "https://example.invalid"},
+		},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{
+			"test": {
+				ClientCertificateData: []byte("cert"),
+				ClientKeyData:         []byte("key"),
+				Token:                 "should-be-ignored",
+			},
+		},
+	}
+
+	config, err := clusterConfigFor(kubeconfig, "test", "test")
+	if err != nil {
+		t.Fatalf("clusterConfigFor returned an error: %v", err)
+	}
+	if string(config.CertData) != "cert" || string(config.KeyData) != "key" {
+		t.Fatalf("expected client-cert auth, got %+v", config)
+	}
+	if config.BearerToken != "" {
+		t.Fatalf("expected no bearer token when a client cert is present, got %q", config.BearerToken)
+	}
+}
+
+func TestClusterConfigForFallsBackToBearerToken(t *testing.T) {
+	kubeconfig := &clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{
+			"test": {Server: "https://example.invalid"},
+		},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{
+			"test": {Token: "abc123"},
+		},
+	}
+
+	config, err := clusterConfigFor(kubeconfig, "test", "test")
+	if err != nil {
+		t.Fatalf("clusterConfigFor returned an error: %v", err)
+	}
+	if config.BearerToken != "abc123" {
+		t.Fatalf("expected bearer token %q, got %q", "abc123", config.BearerToken)
+	}
+}
+
+func TestClusterConfigForFallsBackToExecPlugin(t *testing.T) {
+	kubeconfig := &clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{
+			"test": {Server: "https://example.invalid"},
+		},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{
+			"test": {
+				Exec: &clientcmdapi.ExecConfig{
+					Command:    "aws-iam-authenticator",
+					Args:       []string{"token", "-i", "test"},
+					APIVersion: "client.authentication.k8s.io/v1beta1",
+				},
+			},
+		},
+	}
+
+	config, err := clusterConfigFor(kubeconfig, "test", "test")
+	if err != nil {
+		t.Fatalf("clusterConfigFor returned an error: %v", err)
+	}
+	if config.ExecProviderConfig == nil || config.ExecProviderConfig.Command != "aws-iam-authenticator" {
+		t.Fatalf("expected exec provider config, got %+v", config.ExecProviderConfig)
+	}
+}
+
+func TestClusterConfigForErrorsWithoutUsableCredentials(t *testing.T) {
+	kubeconfig := &clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{
+			"test": {Server: "https://example.invalid"},
+		},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{
+			"test": {},
+		},
+	}
+
+	if _, err := clusterConfigFor(kubeconfig, "test", "test"); err == nil {
+		t.Fatal("expected an error for an auth info with no usable credentials")
+	}
+}
+
+func TestClusterConfigForErrorsOnMissingClusterOrAuthInfo(t *testing.T) {
+	kubeconfig := &clientcmdapi.Config{
+		Clusters:  map[string]*clientcmdapi.Cluster{},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{},
+	}
+
+	if _, err := clusterConfigFor(kubeconfig, "missing-cluster", "missing-authinfo"); err == nil {
+		t.Fatal("expected an error instead of a nil-pointer panic for a kubeconfig missing its current cluster/auth entry")
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2022 Dan Molik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	argoappv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+)
+
+func TestDestinationsEqualIgnoresOrder(t *testing.T) {
+	a := []argoappv1.ApplicationDestination{
+		{Name: "a", Server: "https://a.invalid", Namespace: "*"},
+		{Name: "b", Server: "https://b.invalid", Namespace: "*"},
+	}
+	b := []argoappv1.ApplicationDestination{
+		{Name: "b", Server: "https://b.invalid", Namespace: "*"},
+		{Name: "a", Server: "https://a.invalid", Namespace: "*"},
+	}
+
+	if !destinationsEqual(a, b) {
+		t.Fatal("expected destinationsEqual to ignore ordering")
+	}
+}
+
+func TestDestinationsEqualDetectsDifferentLength(t *testing.T) {
+	a := []argoappv1.ApplicationDestination{{Name: "a", Server: "https://a.invalid", Namespace: "*"}}
+	b := []argoappv1.ApplicationDestination{}
+
+	if destinationsEqual(a, b) {
+		t.Fatal("expected destinationsEqual to report inequality for different-length slices")
+	}
+}
+
+func TestDestinationsEqualDetectsChangedServer(t *testing.T) {
+	a := []argoappv1.ApplicationDestination{{Name: "a", Server: "https://old.invalid", Namespace: "*"}}
+	b := []argoappv1.ApplicationDestination{{Name: "a", Server: "https://new.invalid", Namespace: "*"}}
+
+	if destinationsEqual(a, b) {
+		t.Fatal("expected destinationsEqual to report inequality when a destination's server changes")
+	}
+}
+
+func TestDestinationsEqualDetectsDuplicateVsDistinct(t *testing.T) {
+	a := []argoappv1.ApplicationDestination{
+		{Name: "a", Server: "https://a.invalid", Namespace: "*"},
+		{Name: "a", Server: "https://a.invalid", Namespace: "*"},
+	}
+	b := []argoappv1.ApplicationDestination{
+		{Name: "a", Server: "https://a.invalid", Namespace: "*"},
+		{Name: "b", Server: "https://b.invalid", Namespace: "*"},
+	}
+
+	if destinationsEqual(a, b) {
+		t.Fatal("expected destinationsEqual to tell a duplicated entry apart from two distinct ones")
+	}
+}
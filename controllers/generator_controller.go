@@ -17,28 +17,77 @@ limitations under the License.
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"time"
+	"os"
+	"strings"
+	"text/template"
 
 	argoappv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/json"
 	clientcmd "k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	clusterv1alpha1 "github.com/dmolik/argocd-cluster-register/api/v1alpha1"
+	"github.com/dmolik/argocd-cluster-register/pkg/sources"
 
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	ocmv1 "open-cluster-management.io/api/cluster/v1"
 	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
+// kubeconfigHashAnnotation records the hash of the kubeconfig payload that produced
+// an Argo CD cluster secret, so reconciles triggered by unrelated events can be
+// skipped once the secret already reflects the current kubeconfig.
+const kubeconfigHashAnnotation = "cluster.argoproj.io/kubeconfig-hash"
+
+// sourceRevisionAnnotation records the source object's resource version on
+// the Argo CD cluster secret, regardless of which ClusterSource produced it.
+const sourceRevisionAnnotation = "cluster.argoproj.io/source-revision"
+
+// generatorFinalizer blocks deletion of a Generator (and, for CAPI, the
+// Cluster it registered) until this controller has cleaned up the Argo CD
+// cluster secrets and AppProject destinations it created.
+const generatorFinalizer = "cluster.argoproj.io/generator"
+
+// generatorLabel records which Generator owns an Argo CD cluster secret, so
+// stale secrets can be found and pruned once their cluster disappears.
+const generatorLabel = "cluster.argoproj.io/generator"
+
+// defaultArgoCDNamespace is used when a Generator doesn't set spec.argocd.namespace.
+const defaultArgoCDNamespace = "argocd"
+
+// clusterRegistration is the fully-resolved identity and connection info for
+// a cluster about to be registered with Argo CD: the display name (after
+// spec.clusterNameTemplate), the Argo CD namespace it's registered into, and
+// the server URL (after spec.serverOverride).
+type clusterRegistration struct {
+	KubeConfig *clientcmdapi.Config
+	Name       string
+	Namespace  string
+	Server     string
+	Revision   string
+	Hash       string
+}
+
 // GeneratorReconciler reconciles a Generator object
 type GeneratorReconciler struct {
 	client.Client
@@ -48,81 +97,369 @@ type GeneratorReconciler struct {
 //+kubebuilder:rbac:groups=cluster.argoproj.io,resources=generators,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=cluster.argoproj.io,resources=generators/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=cluster.argoproj.io,resources=generators/finalizers,verbs=update
-//+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status;clusters/finalizers,verbs=get;list;watch
+//+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
+//+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters/finalizers,verbs=get;list;watch;update
 //+kubebuilder:rbac:namespace=argocd,resources=secrets,verbs=create;update;delete;get
 //+kubebuilder:rbac:groups=argoproj.io,resources=appprojects,verbs=update;list;watch;get
 
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.11.0/pkg/reconcile
-func (r *GeneratorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *GeneratorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, reconcileErr error) {
 	log := log.FromContext(ctx)
 
+	timer := prometheus.NewTimer(reconcileDuration)
+	defer func() {
+		timer.ObserveDuration()
+		if reconcileErr != nil {
+			reconcileErrorsTotal.Inc()
+		}
+	}()
+
 	gen := clusterv1alpha1.Generator{}
 	err := r.Get(ctx, req.NamespacedName, &gen)
 	if err != nil {
-		return ctrl.Result{}, err
+		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	clusterList := &capiv1beta1.ClusterList{}
-	err = r.List(ctx, clusterList, client.MatchingLabels{})
-	if err != nil {
-		return ctrl.Result{}, err
+	namespace := argocdNamespace(&gen)
+
+	if !gen.ObjectMeta.DeletionTimestamp.IsZero() {
+		if controllerutil.ContainsFinalizer(&gen, generatorFinalizer) {
+			if err := r.pruneSecrets(ctx, &gen, namespace, nil); err != nil {
+				return ctrl.Result{}, err
+			}
+			if err := r.syncProjects(ctx, &gen, nil, nil); err != nil {
+				return ctrl.Result{}, err
+			}
+			controllerutil.RemoveFinalizer(&gen, generatorFinalizer)
+			if err := r.Update(ctx, &gen); err != nil {
+				return ctrl.Result{}, err
+			}
+			clusterRegistered.DeleteLabelValues(gen.ObjectMeta.Namespace, gen.ObjectMeta.Name)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(&gen, generatorFinalizer) {
+		controllerutil.AddFinalizer(&gen, generatorFinalizer)
+		if err := r.Update(ctx, &gen); err != nil {
+			return ctrl.Result{}, err
+		}
 	}
-	for _, cluster := range clusterList.Items {
-		log.V(0).Info(fmt.Sprintf("found cluster, phase=%s, control_plane_ready=%t, revision=%s, name=%s", cluster.Status.Phase, cluster.Status.ControlPlaneReady, cluster.ResourceVersion, cluster.ObjectMeta.Name)) // , cluster.Status.Conditions))
-		if cluster.Status.Phase == "Deleting" {
-			// delete the cluster secret from argocd
-			kcfg, err := r.getKubeConfig(ctx, &cluster)
+
+	// desired maps each cluster successfully synced this round to its resolved
+	// Argo CD server URL. stillDiscovered holds every live cluster's
+	// registered name regardless of whether this round's sync succeeded, and
+	// is what pruning/destination-removal is based on: a transient failure
+	// partway through a cluster's loop body must not make a still-live
+	// cluster look gone to pruneSecrets/syncProjects.
+	desired := map[string]string{}
+	stillDiscovered := map[string]bool{}
+	clusterStatuses := []clusterv1alpha1.ClusterStatus{}
+	kubeconfigsAvailable := true
+	secretsSynced := true
+	for _, srcSpec := range gen.Spec.Sources {
+		src, err := r.sourceFor(srcSpec.Type)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		selector, err := metav1.LabelSelectorAsSelector(srcSpec.Selector)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		discovered, err := src.List(ctx, selector)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		for _, cluster := range discovered {
+			log.V(0).Info(fmt.Sprintf("found cluster, source=%s, deleting=%t, revision=%s, name=%s", src.Name(), cluster.Deleting, cluster.Revision, cluster.Name))
+			status := clusterv1alpha1.ClusterStatus{Name: cluster.Name, LastSyncTime: metav1.Now()}
+
+			// Resolve the registered name before the KubeConfig fetch below,
+			// which can fail transiently, so that failure doesn't prevent us
+			// from recognizing this cluster as still discovered. This only
+			// yields a name here when spec.clusterNameTemplate is set; the
+			// template-less fallback is the kubeconfig's own cluster name,
+			// which isn't known until the fetch succeeds.
+			clusterName, err := r.resolveClusterName(&gen, cluster, "")
+			if err != nil {
+				status.Phase = "Error"
+				status.Error = err.Error()
+				clusterStatuses = append(clusterStatuses, status)
+				reconcileErr = err
+				continue
+			}
+			if clusterName != "" && !cluster.Deleting {
+				stillDiscovered[clusterName] = true
+			}
+
+			kcfg, err := src.KubeConfig(ctx, cluster)
 			if err != nil {
-				if errors.IsNotFound(err) {
+				if cluster.Deleting && errors.IsNotFound(err) {
 					continue
 				}
-				return ctrl.Result{}, err
+				kubeconfigsAvailable = false
+				status.Phase = "Error"
+				status.Error = err.Error()
+				clusterStatuses = append(clusterStatuses, status)
+				reconcileErr = err
+				continue
 			}
-			if _, err = r.deleteSecret(ctx, kcfg); err != nil {
-				return ctrl.Result{}, err
+			kcClusterName := kcfg.Contexts[kcfg.CurrentContext].Cluster
+			if clusterName == "" {
+				clusterName, err = r.resolveClusterName(&gen, cluster, kcClusterName)
+				if err != nil {
+					status.Phase = "Error"
+					status.Error = err.Error()
+					clusterStatuses = append(clusterStatuses, status)
+					reconcileErr = err
+					continue
+				}
+				if !cluster.Deleting {
+					stillDiscovered[clusterName] = true
+				}
 			}
-		}
-		if cluster.Status.Phase != "Deleting" {
-			// get the secret and push it into argocd
-			kcfg, err := r.getKubeConfig(ctx, &cluster)
+			status.Name = clusterName
+
+			if cluster.Deleting {
+				// delete the cluster secret from argocd
+				if _, err = r.deleteSecret(ctx, clusterName, namespace); err != nil {
+					status.Phase = "Error"
+					status.Error = err.Error()
+					clusterStatuses = append(clusterStatuses, status)
+					reconcileErr = err
+					continue
+				}
+				if src.Name() == sources.TypeCAPI {
+					if err := r.removeClusterFinalizer(ctx, cluster); err != nil {
+						status.Phase = "Error"
+						status.Error = err.Error()
+						clusterStatuses = append(clusterStatuses, status)
+						reconcileErr = err
+						continue
+					}
+				}
+				status.Phase = "Deleted"
+				clusterStatuses = append(clusterStatuses, status)
+				continue
+			}
+			if src.Name() == sources.TypeCAPI {
+				if err := r.ensureClusterFinalizer(ctx, cluster); err != nil {
+					status.Phase = "Error"
+					status.Error = err.Error()
+					clusterStatuses = append(clusterStatuses, status)
+					reconcileErr = err
+					continue
+				}
+			}
+
+			server := kcfg.Clusters[kcClusterName].Server
+			if gen.Spec.ServerOverride != "" {
+				server = gen.Spec.ServerOverride
+			}
+			hash, err := kubeconfigHash(kcfg)
 			if err != nil {
-				return ctrl.Result{}, err
+				status.Phase = "Error"
+				status.Error = err.Error()
+				clusterStatuses = append(clusterStatuses, status)
+				reconcileErr = err
+				continue
 			}
-			if _, err = r.ensureSecret(ctx, kcfg, &cluster); err != nil {
-				return ctrl.Result{}, err
+			status.Hash = hash
+			reg := clusterRegistration{
+				KubeConfig: kcfg,
+				Name:       clusterName,
+				Namespace:  namespace,
+				Server:     server,
+				Revision:   cluster.Revision,
+				Hash:       hash,
 			}
-			if err = r.addToProject(ctx, kcfg, &gen); err != nil {
-				return ctrl.Result{}, err
+			if _, err = r.ensureSecret(ctx, reg, &gen); err != nil {
+				secretsSynced = false
+				status.Phase = "Error"
+				status.Error = err.Error()
+				clusterStatuses = append(clusterStatuses, status)
+				reconcileErr = err
+				continue
 			}
+			desired[clusterName] = server
+			status.Phase = "Registered"
+			clusterStatuses = append(clusterStatuses, status)
 		}
 	}
-	oneMinute, err := time.ParseDuration("1m")
-	if err != nil {
+
+	if err := r.pruneSecrets(ctx, &gen, namespace, stillDiscovered); err != nil {
+		return ctrl.Result{}, err
+	}
+	projectsSynced := r.syncProjects(ctx, &gen, desired, stillDiscovered) == nil
+
+	clusterRegistered.WithLabelValues(gen.ObjectMeta.Namespace, gen.ObjectMeta.Name).Set(float64(len(desired)))
+	gen.Status.ObservedGeneration = gen.ObjectMeta.Generation
+	gen.Status.ClusterCount = len(clusterStatuses)
+	gen.Status.RegisteredCount = len(desired)
+	gen.Status.Clusters = clusterStatuses
+	gen.Status.Conditions = generatorConditions(&gen, kubeconfigsAvailable, secretsSynced, projectsSynced)
+	if err := r.Status().Update(ctx, &gen); err != nil {
 		return ctrl.Result{}, err
 	}
-	return ctrl.Result{RequeueAfter: oneMinute}, nil
+
+	return ctrl.Result{}, reconcileErr
+}
+
+// generatorConditions derives the Ready, KubeconfigAvailable, SecretSynced,
+// and ProjectSynced conditions for a reconcile from the outcomes observed
+// while registering its clusters.
+func generatorConditions(gen *clusterv1alpha1.Generator, kubeconfigsAvailable, secretsSynced, projectsSynced bool) []metav1.Condition {
+	now := metav1.Now()
+	condition := func(condType string, ok bool, reason string) metav1.Condition {
+		status := metav1.ConditionTrue
+		if !ok {
+			status = metav1.ConditionFalse
+		}
+		return metav1.Condition{
+			Type:               condType,
+			Status:             status,
+			Reason:             reason,
+			ObservedGeneration: gen.ObjectMeta.Generation,
+			LastTransitionTime: now,
+		}
+	}
+
+	return []metav1.Condition{
+		condition("KubeconfigAvailable", kubeconfigsAvailable, conditionReason(kubeconfigsAvailable, "Available", "FetchFailed")),
+		condition("SecretSynced", secretsSynced, conditionReason(secretsSynced, "Synced", "SyncFailed")),
+		condition("ProjectSynced", projectsSynced, conditionReason(projectsSynced, "Synced", "SyncFailed")),
+		condition("Ready", kubeconfigsAvailable && secretsSynced && projectsSynced, conditionReason(kubeconfigsAvailable && secretsSynced && projectsSynced, "RegistrationHealthy", "RegistrationDegraded")),
+	}
+}
+
+// conditionReason picks the success or failure reason string for a condition.
+func conditionReason(ok bool, successReason, failureReason string) string {
+	if ok {
+		return successReason
+	}
+	return failureReason
+}
+
+// argocdNamespace returns the namespace a Generator registers clusters into,
+// defaulting to defaultArgoCDNamespace.
+func argocdNamespace(gen *clusterv1alpha1.Generator) string {
+	if gen.Spec.Argocd != nil && gen.Spec.Argocd.Namespace != "" {
+		return gen.Spec.Argocd.Namespace
+	}
+	return defaultArgoCDNamespace
 }
 
-func (r *GeneratorReconciler) getKubeConfig(ctx context.Context, cluster *capiv1beta1.Cluster) (*clientcmdapi.Config, error) {
-	secret := corev1.Secret{}
-	secretReq := types.NamespacedName{}
-	secretReq.Name = cluster.ObjectMeta.Name + "-kubeconfig"
-	secretReq.Namespace = cluster.ObjectMeta.Namespace
-	err := r.Get(ctx, secretReq, &secret)
+// resolveClusterName evaluates a Generator's spec.clusterNameTemplate over
+// the discovered cluster to produce the name Argo CD registers it under,
+// falling back to the kubeconfig's own cluster name when no template is set.
+func (r *GeneratorReconciler) resolveClusterName(gen *clusterv1alpha1.Generator, cluster sources.DiscoveredCluster, kcClusterName string) (string, error) {
+	if gen.Spec.ClusterNameTemplate == "" {
+		return kcClusterName, nil
+	}
+	tmpl, err := template.New("clusterName").Parse(gen.Spec.ClusterNameTemplate)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("parsing spec.clusterNameTemplate: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, cluster); err != nil {
+		return "", fmt.Errorf("evaluating spec.clusterNameTemplate for cluster %q: %w", cluster.Name, err)
+	}
+	return buf.String(), nil
+}
+
+// ensureClusterFinalizer adds generatorFinalizer to the CAPI Cluster backing
+// a discovered cluster, so the Cluster can't be force-deleted out from under
+// a registered Argo CD secret.
+func (r *GeneratorReconciler) ensureClusterFinalizer(ctx context.Context, cluster sources.DiscoveredCluster) error {
+	capiCluster := capiv1beta1.Cluster{}
+	clusterReq := types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}
+	if err := r.Get(ctx, clusterReq, &capiCluster); err != nil {
+		return err
+	}
+	if controllerutil.ContainsFinalizer(&capiCluster, generatorFinalizer) {
+		return nil
+	}
+	controllerutil.AddFinalizer(&capiCluster, generatorFinalizer)
+	return r.Update(ctx, &capiCluster)
+}
+
+// removeClusterFinalizer removes generatorFinalizer from the CAPI Cluster
+// backing a discovered cluster, once its Argo CD secret has been deleted, so
+// a Cluster being torn down isn't left stuck in Terminating waiting on a
+// finalizer this controller will never clear otherwise.
+func (r *GeneratorReconciler) removeClusterFinalizer(ctx context.Context, cluster sources.DiscoveredCluster) error {
+	capiCluster := capiv1beta1.Cluster{}
+	clusterReq := types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}
+	if err := r.Get(ctx, clusterReq, &capiCluster); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !controllerutil.ContainsFinalizer(&capiCluster, generatorFinalizer) {
+		return nil
 	}
-	kubeconfig, err := clientcmd.Load(secret.Data["value"])
+	controllerutil.RemoveFinalizer(&capiCluster, generatorFinalizer)
+	return r.Update(ctx, &capiCluster)
+}
+
+// pruneSecrets deletes Argo CD cluster secrets owned by gen whose cluster
+// name is not present in stillDiscovered, so clusters that disappeared
+// (force deletion, a Generator deletion, or falling out of a source's
+// selector) don't leak their secret. stillDiscovered is every cluster still
+// seen this reconcile, regardless of whether this round's sync succeeded for
+// it, so a transient per-cluster failure doesn't prune a live cluster's
+// secret.
+func (r *GeneratorReconciler) pruneSecrets(ctx context.Context, gen *clusterv1alpha1.Generator, namespace string, stillDiscovered map[string]bool) error {
+	owned := corev1.SecretList{}
+	err := r.List(ctx, &owned,
+		client.InNamespace(namespace),
+		client.MatchingLabels{generatorLabel: gen.ObjectMeta.Name},
+	)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	for i := range owned.Items {
+		secret := owned.Items[i]
+		clusterName := strings.TrimSuffix(secret.ObjectMeta.Name, "-cluster-secret")
+		if stillDiscovered[clusterName] {
+			continue
+		}
+		if err := r.Delete(ctx, &secret); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// sourceFor resolves the ClusterSource implementation configured for a
+// Generator's spec.sources[].type.
+func (r *GeneratorReconciler) sourceFor(sourceType string) (sources.ClusterSource, error) {
+	switch sourceType {
+	case sources.TypeCAPI:
+		return sources.NewCAPISource(r.Client), nil
+	case sources.TypeHive:
+		return sources.NewHiveSource(r.Client), nil
+	case sources.TypeOCM:
+		return sources.NewOCMSource(r.Client), nil
+	case sources.TypeSecret:
+		return sources.NewSecretSource(r.Client), nil
+	default:
+		return nil, fmt.Errorf("generator source type %q is not supported", sourceType)
+	}
+}
+
+// kubeconfigHash returns a stable digest of a kubeconfig so ensureSecret can
+// tell whether the upstream cluster source actually changed.
+func kubeconfigHash(kubeconfig *clientcmdapi.Config) (string, error) {
+	raw, err := clientcmd.Write(*kubeconfig)
+	if err != nil {
+		return "", err
 	}
-	return kubeconfig, nil
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
 }
 
-func (r *GeneratorReconciler) deleteSecret(ctx context.Context, kubeconfig *clientcmdapi.Config) (ctrl.Result, error) {
+func (r *GeneratorReconciler) deleteSecret(ctx context.Context, clusterName, namespace string) (ctrl.Result, error) {
 	log := log.FromContext(ctx)
-	clusterName := kubeconfig.Contexts[kubeconfig.CurrentContext].Cluster
 	log.V(0).Info("deleting " + clusterName)
 	secret := corev1.Secret{
 		TypeMeta: metav1.TypeMeta{
@@ -131,7 +468,7 @@ func (r *GeneratorReconciler) deleteSecret(ctx context.Context, kubeconfig *clie
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      clusterName + "-cluster-secret",
-			Namespace: "argocd",
+			Namespace: namespace,
 		},
 	}
 	err := r.Delete(ctx, &secret)
@@ -144,18 +481,83 @@ func (r *GeneratorReconciler) deleteSecret(ctx context.Context, kubeconfig *clie
 	return ctrl.Result{}, nil
 }
 
-func (r *GeneratorReconciler) ensureSecret(ctx context.Context, kubeconfig *clientcmdapi.Config, cluster *capiv1beta1.Cluster) (ctrl.Result, error) {
-	clusterName := kubeconfig.Contexts[kubeconfig.CurrentContext].Cluster
-	authName := kubeconfig.Contexts[kubeconfig.CurrentContext].AuthInfo
-	config := argoappv1.ClusterConfig{
+// clusterConfigFor translates the auth material referenced by authName into the
+// argoappv1.ClusterConfig Argo CD expects, picking the first applicable mode in
+// order: client certificate, bearer token, exec plugin. Kubeconfigs produced by
+// managed-cloud CAPI providers (EKS/AKS/GKE) commonly rely on the latter two.
+func clusterConfigFor(kubeconfig *clientcmdapi.Config, clusterName, authName string) (*argoappv1.ClusterConfig, error) {
+	kcCluster := kubeconfig.Clusters[clusterName]
+	authInfo := kubeconfig.AuthInfos[authName]
+	if kcCluster == nil || authInfo == nil {
+		return nil, fmt.Errorf("kubeconfig has no cluster entry %q or no auth info entry %q", clusterName, authName)
+	}
+
+	config := &argoappv1.ClusterConfig{
 		TLSClientConfig: argoappv1.TLSClientConfig{
-			CAData:   kubeconfig.Clusters[clusterName].CertificateAuthorityData,
-			CertData: kubeconfig.AuthInfos[authName].ClientCertificateData,
-			KeyData:  kubeconfig.AuthInfos[authName].ClientKeyData,
+			Insecure:   kcCluster.InsecureSkipTLSVerify,
+			ServerName: kcCluster.TLSServerName,
+			CAData:     kcCluster.CertificateAuthorityData,
 		},
 	}
-	configByte, err := json.Marshal(&config)
+
+	switch {
+	case len(authInfo.ClientCertificateData) > 0 && len(authInfo.ClientKeyData) > 0:
+		config.CertData = authInfo.ClientCertificateData
+		config.KeyData = authInfo.ClientKeyData
+	case authInfo.Token != "" || authInfo.TokenFile != "":
+		token := authInfo.Token
+		if token == "" {
+			data, err := os.ReadFile(authInfo.TokenFile)
+			if err != nil {
+				return nil, fmt.Errorf("reading token file %q for auth info %q: %w", authInfo.TokenFile, authName, err)
+			}
+			token = string(data)
+		}
+		config.BearerToken = token
+	case authInfo.Exec != nil:
+		env := map[string]string{}
+		for _, e := range authInfo.Exec.Env {
+			env[e.Name] = e.Value
+		}
+		config.ExecProviderConfig = &argoappv1.ExecProviderConfig{
+			Command:     authInfo.Exec.Command,
+			Args:        authInfo.Exec.Args,
+			Env:         env,
+			APIVersion:  authInfo.Exec.APIVersion,
+			InstallHint: authInfo.Exec.InstallHint,
+		}
+	default:
+		return nil, fmt.Errorf("auth info %q for cluster %q has no usable credentials (no client cert, token, or exec plugin)", authName, clusterName)
+	}
+
+	return config, nil
+}
+
+func (r *GeneratorReconciler) ensureSecret(ctx context.Context, reg clusterRegistration, gen *clusterv1alpha1.Generator) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+	kcClusterName := reg.KubeConfig.Contexts[reg.KubeConfig.CurrentContext].Cluster
+	authName := reg.KubeConfig.Contexts[reg.KubeConfig.CurrentContext].AuthInfo
+
+	secretName := reg.Name + "-cluster-secret"
+	existing := corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: secretName, Namespace: reg.Namespace}, &existing)
+	if err != nil && !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	if err == nil && existing.Annotations[kubeconfigHashAnnotation] == reg.Hash {
+		log.V(1).Info("kubeconfig unchanged, skipping cluster secret sync", "cluster", reg.Name)
+		secretSyncsTotal.WithLabelValues("unchanged").Inc()
+		return ctrl.Result{}, nil
+	}
+
+	config, err := clusterConfigFor(reg.KubeConfig, kcClusterName, authName)
+	if err != nil {
+		secretSyncsTotal.WithLabelValues("error").Inc()
+		return ctrl.Result{}, err
+	}
+	configByte, err := json.Marshal(config)
 	if err != nil {
+		secretSyncsTotal.WithLabelValues("error").Inc()
 		return ctrl.Result{}, err
 	}
 
@@ -165,57 +567,316 @@ func (r *GeneratorReconciler) ensureSecret(ctx context.Context, kubeconfig *clie
 			APIVersion: "v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      clusterName + "-cluster-secret",
-			Namespace: "argocd",
+			Name:      secretName,
+			Namespace: reg.Namespace,
 			Labels: map[string]string{
 				"app.kubernetes.io/part-of":      "argocd",
 				"argocd.argoproj.io/secret-type": "cluster",
-				"cluster.x-k8s.io/cluster-name":  clusterName,
+				"cluster.x-k8s.io/cluster-name":  reg.Name,
+				generatorLabel:                   gen.ObjectMeta.Name,
 			},
 			Annotations: map[string]string{
-				"cluster.x-k8s.io/revision": cluster.ResourceVersion,
+				sourceRevisionAnnotation: reg.Revision,
+				kubeconfigHashAnnotation: reg.Hash,
 			},
 		},
 		StringData: map[string]string{
-			"name":   clusterName,
-			"server": kubeconfig.Clusters[clusterName].Server,
+			"name":   reg.Name,
+			"server": reg.Server,
 			"config": string(configByte),
 		},
 		Type: "Opaque",
 	}
-	err = r.Create(ctx, &secret)
-	if err != nil {
-		if errors.IsAlreadyExists(err) {
-			err = r.Update(ctx, &secret)
+	if errors.IsNotFound(err) {
+		if err := r.Create(ctx, &secret); err != nil {
+			secretSyncsTotal.WithLabelValues("error").Inc()
 			return ctrl.Result{}, err
 		}
+		secretSyncsTotal.WithLabelValues("created").Inc()
+		return ctrl.Result{}, nil
+	}
+	secret.ResourceVersion = existing.ResourceVersion
+	if err := r.Update(ctx, &secret); err != nil {
+		secretSyncsTotal.WithLabelValues("error").Inc()
 		return ctrl.Result{}, err
 	}
+	secretSyncsTotal.WithLabelValues("updated").Inc()
 	return ctrl.Result{}, nil
 }
-func (r *GeneratorReconciler) addToProject(ctx context.Context, kubeconfig *clientcmdapi.Config, gen *clusterv1alpha1.Generator) error {
-	clusterName := kubeconfig.Contexts[kubeconfig.CurrentContext].Cluster
-	if gen.Spec.AppProjectName == "" {
+
+// syncProjects reconciles every AppProject named in gen.Spec.AppProjectNames
+// so it carries exactly one destination per cluster in desired, leaves a
+// previously-managed destination untouched (rather than removing it) when
+// its cluster is still in stillDiscovered but just didn't sync this round,
+// removes it only once its cluster drops out of stillDiscovered entirely,
+// and records the outcome of each project's sync on gen.Status.Projects. The
+// caller is responsible for persisting gen.Status.
+func (r *GeneratorReconciler) syncProjects(ctx context.Context, gen *clusterv1alpha1.Generator, desired map[string]string, stillDiscovered map[string]bool) error {
+	if len(gen.Spec.AppProjectNames) == 0 {
+		gen.Status.Projects = nil
 		return nil
 	}
-	project := argoappv1.AppProject{}
-	projectReq := types.NamespacedName{
-		Name:      gen.ObjectMeta.Name,
-		Namespace: gen.ObjectMeta.Namespace,
+
+	previouslyManaged := make(map[string][]string, len(gen.Status.Projects))
+	for _, ps := range gen.Status.Projects {
+		previouslyManaged[ps.ProjectName] = ps.DestinationNames
 	}
-	err := r.Get(ctx, projectReq, &project)
-	if err != nil {
-		return err
+
+	managedNames := make([]string, 0, len(desired))
+	for name := range desired {
+		managedNames = append(managedNames, name)
+	}
+
+	statuses := make([]clusterv1alpha1.ProjectStatus, 0, len(gen.Spec.AppProjectNames))
+	var syncErr error
+	for _, projectName := range gen.Spec.AppProjectNames {
+		condition := metav1.Condition{
+			Type:               "Synced",
+			Status:             metav1.ConditionTrue,
+			Reason:             "DestinationsReconciled",
+			Message:            fmt.Sprintf("destinations reconciled for %d cluster(s)", len(desired)),
+			ObservedGeneration: gen.ObjectMeta.Generation,
+			LastTransitionTime: metav1.Now(),
+		}
+		// names reflects what this Generator manages on projectName after this
+		// sync. On failure we keep the previous set, since we don't know
+		// whether the update applied, so the next reconcile still knows what
+		// to clean up.
+		names := previouslyManaged[projectName]
+		if err := r.syncProjectDestinations(ctx, argocdNamespace(gen), projectName, desired, names, stillDiscovered); err != nil {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "SyncFailed"
+			condition.Message = err.Error()
+			syncErr = err
+		} else {
+			names = append([]string{}, managedNames...)
+			for _, name := range previouslyManaged[projectName] {
+				if _, ok := desired[name]; ok {
+					continue
+				}
+				if stillDiscovered[name] {
+					names = append(names, name)
+				}
+			}
+		}
+		statuses = append(statuses, clusterv1alpha1.ProjectStatus{
+			ProjectName:        projectName,
+			ObservedGeneration: gen.ObjectMeta.Generation,
+			DestinationNames:   names,
+			Conditions:         []metav1.Condition{condition},
+		})
 	}
-	project.Spec.Destinations = append(project.Spec.Destinations, argoappv1.ApplicationDestination{
-		Name: clusterName,
+
+	gen.Status.Projects = statuses
+	return syncErr
+}
+
+// syncProjectDestinations ensures projectName carries exactly one
+// {Name, Server, Namespace: "*"} destination per cluster in desired, without
+// touching destinations it doesn't own: an existing destination is only
+// added or refreshed if its name is in desired, and only removed if
+// previouslyManaged says this Generator put it there and its cluster has
+// also dropped out of stillDiscovered, i.e. it's actually gone rather than
+// just not synced this round. Everything else - another Generator's
+// destinations, or ones an operator added by hand - passes through
+// unchanged. Retries on update conflicts from concurrent editors instead of
+// racily overwriting them.
+func (r *GeneratorReconciler) syncProjectDestinations(ctx context.Context, namespace, projectName string, desired map[string]string, previouslyManaged []string, stillDiscovered map[string]bool) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		project := argoappv1.AppProject{}
+		projectReq := types.NamespacedName{Name: projectName, Namespace: namespace}
+		if err := r.Get(ctx, projectReq, &project); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		managed := make(map[string]bool, len(previouslyManaged))
+		for _, name := range previouslyManaged {
+			managed[name] = true
+		}
+
+		destinations := make([]argoappv1.ApplicationDestination, 0, len(project.Spec.Destinations)+len(desired))
+		synced := make(map[string]bool, len(desired))
+		for _, dest := range project.Spec.Destinations {
+			if server, ok := desired[dest.Name]; ok {
+				if synced[dest.Name] {
+					continue
+				}
+				destinations = append(destinations, argoappv1.ApplicationDestination{
+					Name:      dest.Name,
+					Server:    server,
+					Namespace: "*",
+				})
+				synced[dest.Name] = true
+				continue
+			}
+			if managed[dest.Name] {
+				if stillDiscovered[dest.Name] {
+					if synced[dest.Name] {
+						continue
+					}
+					// still live, just not synced this round - leave it alone.
+					destinations = append(destinations, dest)
+					synced[dest.Name] = true
+					continue
+				}
+				// this Generator used to manage dest.Name, but its cluster is gone now.
+				continue
+			}
+			destinations = append(destinations, dest)
+		}
+		for clusterName, server := range desired {
+			if synced[clusterName] {
+				continue
+			}
+			destinations = append(destinations, argoappv1.ApplicationDestination{
+				Name:      clusterName,
+				Server:    server,
+				Namespace: "*",
+			})
+		}
+
+		if destinationsEqual(project.Spec.Destinations, destinations) {
+			return nil
+		}
+		project.Spec.Destinations = destinations
+		return r.Update(ctx, &project)
 	})
-	return r.Update(ctx, &project)
 }
 
-// SetupWithManager sets up the controller with the Manager.
+// destinationsEqual reports whether a and b contain the same set of
+// {Name, Server, Namespace} destinations, ignoring order, so
+// syncProjectDestinations can skip a no-op update.
+func destinationsEqual(a, b []argoappv1.ApplicationDestination) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	key := func(d argoappv1.ApplicationDestination) string {
+		return d.Name + "|" + d.Server + "|" + d.Namespace
+	}
+	counts := map[string]int{}
+	for _, dest := range a {
+		counts[key(dest)]++
+	}
+	for _, dest := range b {
+		counts[key(dest)]--
+	}
+	for _, count := range counts {
+		if count != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// SetupWithManager sets up the controller with the Manager. In addition to
+// owning Generator, it always watches CAPI Clusters and kubeconfig Secret
+// events, so registration reacts immediately instead of relying on a fixed
+// poll interval. Hive and OCM are optional operators: watching their types
+// unconditionally makes the manager's cache fail to start in any cluster
+// that doesn't have those CRDs installed, so those two watches are only
+// added when a Generator existing at manager setup configures that source
+// type.
 func (r *GeneratorReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	sourceTypes, err := r.configuredSourceTypes(mgr)
+	if err != nil {
+		return fmt.Errorf("listing generators to determine which source watches to register: %w", err)
+	}
+
+	bldr := ctrl.NewControllerManagedBy(mgr).
 		For(&clusterv1alpha1.Generator{}).
-		Complete(r)
+		Watches(
+			&source.Kind{Type: &capiv1beta1.Cluster{}},
+			handler.EnqueueRequestsFromMapFunc(r.generatorsForObject),
+		).
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(r.generatorsForKubeconfigSecret),
+		)
+	if sourceTypes[sources.TypeHive] {
+		bldr = bldr.Watches(
+			&source.Kind{Type: &hivev1.ClusterDeployment{}},
+			handler.EnqueueRequestsFromMapFunc(r.generatorsForObject),
+		)
+	}
+	if sourceTypes[sources.TypeOCM] {
+		bldr = bldr.Watches(
+			&source.Kind{Type: &ocmv1.ManagedCluster{}},
+			handler.EnqueueRequestsFromMapFunc(r.generatorsForObject),
+		)
+	}
+	return bldr.Complete(r)
+}
+
+// configuredSourceTypes returns the set of spec.sources[].type values used by
+// any Generator in the cluster, read directly from the API server via the
+// manager's uncached reader since the cache isn't started yet at this point
+// in manager setup.
+func (r *GeneratorReconciler) configuredSourceTypes(mgr ctrl.Manager) (map[string]bool, error) {
+	generators := clusterv1alpha1.GeneratorList{}
+	if err := mgr.GetAPIReader().List(context.TODO(), &generators); err != nil {
+		return nil, err
+	}
+	inUse := map[string]bool{}
+	for _, gen := range generators.Items {
+		for _, src := range gen.Spec.Sources {
+			inUse[src.Type] = true
+		}
+	}
+	return inUse, nil
+}
+
+// generatorsForObject maps a cluster-source event (CAPI Cluster, Hive
+// ClusterDeployment, or OCM ManagedCluster) to the Generators with a source
+// selector matching its labels.
+func (r *GeneratorReconciler) generatorsForObject(obj client.Object) []reconcile.Request {
+	return r.generatorsMatchingLabels(obj.GetLabels())
+}
+
+// generatorsForKubeconfigSecret maps a `<cluster>-kubeconfig` Secret event to
+// the Generators with a source selector matching its labels, or, if it
+// belongs to a CAPI Cluster, that Cluster's labels. Secrets with other names
+// are ignored.
+func (r *GeneratorReconciler) generatorsForKubeconfigSecret(obj client.Object) []reconcile.Request {
+	secret, ok := obj.(*corev1.Secret)
+	if !ok || !strings.HasSuffix(secret.ObjectMeta.Name, "-kubeconfig") {
+		return nil
+	}
+	clusterName := strings.TrimSuffix(secret.ObjectMeta.Name, "-kubeconfig")
+	cluster := capiv1beta1.Cluster{}
+	clusterReq := types.NamespacedName{Name: clusterName, Namespace: secret.ObjectMeta.Namespace}
+	if err := r.Get(context.TODO(), clusterReq, &cluster); err == nil {
+		return r.generatorsMatchingLabels(cluster.ObjectMeta.Labels)
+	}
+	return r.generatorsMatchingLabels(secret.ObjectMeta.Labels)
+}
+
+// generatorsMatchingLabels lists all Generators and returns reconcile
+// requests for those with at least one configured source selector matching
+// objectLabels.
+func (r *GeneratorReconciler) generatorsMatchingLabels(objectLabels map[string]string) []reconcile.Request {
+	ctx := context.TODO()
+	generators := clusterv1alpha1.GeneratorList{}
+	if err := r.List(ctx, &generators); err != nil {
+		log.FromContext(ctx).Error(err, "listing generators for watch event")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, gen := range generators.Items {
+		for _, srcSpec := range gen.Spec.Sources {
+			selector, err := metav1.LabelSelectorAsSelector(srcSpec.Selector)
+			if err != nil {
+				continue
+			}
+			if selector.Matches(labels.Set(objectLabels)) {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      gen.ObjectMeta.Name,
+						Namespace: gen.ObjectMeta.Namespace,
+					},
+				})
+				break
+			}
+		}
+	}
+	return requests
 }
@@ -0,0 +1,64 @@
+/*
+Copyright 2022 Dan Molik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// secretSyncsTotal counts every Argo CD cluster secret sync attempt,
+	// labeled by outcome, so a sudden rise in "error" indicates stalled
+	// registration.
+	secretSyncsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "argocd_register_secret_syncs_total",
+		Help: "Total number of Argo CD cluster secret syncs, by result (created, updated, unchanged, error).",
+	}, []string{"result"})
+
+	// clusterRegistered reports how many clusters are currently registered
+	// with Argo CD, labeled by the Generator that registered them, so one
+	// Generator's count doesn't overwrite another's on the next reconcile.
+	clusterRegistered = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "argocd_register_cluster_registered",
+		Help: "Number of clusters currently registered with Argo CD, by generator namespace/name.",
+	}, []string{"generator_namespace", "generator_name"})
+
+	// reconcileErrorsTotal counts Generator reconciles that returned an
+	// error, regardless of which step failed.
+	reconcileErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "argocd_register_reconcile_errors_total",
+		Help: "Total number of Generator reconcile errors.",
+	})
+
+	// reconcileDuration tracks how long each Generator reconcile takes, so
+	// stalled or slow upstream sources show up as a shifting histogram.
+	reconcileDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "argocd_register_reconcile_duration_seconds",
+		Help:    "Duration of Generator reconcile calls, in seconds.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		secretSyncsTotal,
+		clusterRegistered,
+		reconcileErrorsTotal,
+		reconcileDuration,
+	)
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2022 Dan Molik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	clientcmd "k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// CAPISource discovers clusters from Cluster API Cluster objects, reading
+// the `<cluster>-kubeconfig` Secret that the CAPI provider generates
+// alongside each one.
+type CAPISource struct {
+	Client client.Client
+}
+
+// NewCAPISource returns a ClusterSource backed by Cluster API.
+func NewCAPISource(c client.Client) *CAPISource {
+	return &CAPISource{Client: c}
+}
+
+func (s *CAPISource) Name() string { return TypeCAPI }
+
+func (s *CAPISource) List(ctx context.Context, selector labels.Selector) ([]DiscoveredCluster, error) {
+	list := capiv1beta1.ClusterList{}
+	if err := s.Client.List(ctx, &list, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	discovered := make([]DiscoveredCluster, 0, len(list.Items))
+	for _, cluster := range list.Items {
+		discovered = append(discovered, DiscoveredCluster{
+			Name:      cluster.ObjectMeta.Name,
+			Namespace: cluster.ObjectMeta.Namespace,
+			Labels:    cluster.ObjectMeta.Labels,
+			Deleting:  cluster.Status.Phase == "Deleting",
+			Revision:  cluster.ResourceVersion,
+		})
+	}
+	return discovered, nil
+}
+
+func (s *CAPISource) KubeConfig(ctx context.Context, cluster DiscoveredCluster) (*clientcmdapi.Config, error) {
+	secret := corev1.Secret{}
+	secretReq := types.NamespacedName{Name: cluster.Name + "-kubeconfig", Namespace: cluster.Namespace}
+	if err := s.Client.Get(ctx, secretReq, &secret); err != nil {
+		return nil, err
+	}
+	kubeconfig, err := clientcmd.Load(secret.Data["value"])
+	if err != nil {
+		return nil, fmt.Errorf("parsing kubeconfig for cluster %q: %w", cluster.Name, err)
+	}
+	return kubeconfig, nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2022 Dan Molik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	clientcmd "k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+)
+
+// HiveSource discovers clusters from Hive ClusterDeployment objects, reading
+// the admin kubeconfig Secret referenced by
+// spec.clusterMetadata.adminKubeconfigSecretRef.
+type HiveSource struct {
+	Client client.Client
+}
+
+// NewHiveSource returns a ClusterSource backed by Hive ClusterDeployment.
+func NewHiveSource(c client.Client) *HiveSource {
+	return &HiveSource{Client: c}
+}
+
+func (s *HiveSource) Name() string { return TypeHive }
+
+func (s *HiveSource) List(ctx context.Context, selector labels.Selector) ([]DiscoveredCluster, error) {
+	list := hivev1.ClusterDeploymentList{}
+	if err := s.Client.List(ctx, &list, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	discovered := make([]DiscoveredCluster, 0, len(list.Items))
+	for _, cd := range list.Items {
+		discovered = append(discovered, DiscoveredCluster{
+			Name:      cd.ObjectMeta.Name,
+			Namespace: cd.ObjectMeta.Namespace,
+			Labels:    cd.ObjectMeta.Labels,
+			Deleting:  cd.DeletionTimestamp != nil,
+			Revision:  cd.ResourceVersion,
+		})
+	}
+	return discovered, nil
+}
+
+func (s *HiveSource) KubeConfig(ctx context.Context, cluster DiscoveredCluster) (*clientcmdapi.Config, error) {
+	cd := hivev1.ClusterDeployment{}
+	cdReq := types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}
+	if err := s.Client.Get(ctx, cdReq, &cd); err != nil {
+		return nil, err
+	}
+	meta := cd.Spec.ClusterMetadata
+	if meta == nil || meta.AdminKubeconfigSecretRef.Name == "" {
+		return nil, fmt.Errorf("clusterdeployment %q has no admin kubeconfig secret yet", cluster.Name)
+	}
+	secret := corev1.Secret{}
+	secretReq := types.NamespacedName{Name: meta.AdminKubeconfigSecretRef.Name, Namespace: cluster.Namespace}
+	if err := s.Client.Get(ctx, secretReq, &secret); err != nil {
+		return nil, err
+	}
+	kubeconfig, err := clientcmd.Load(secret.Data["kubeconfig"])
+	if err != nil {
+		return nil, fmt.Errorf("parsing admin kubeconfig for clusterdeployment %q: %w", cluster.Name, err)
+	}
+	return kubeconfig, nil
+}
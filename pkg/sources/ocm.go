@@ -0,0 +1,80 @@
+/*
+Copyright 2022 Dan Molik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	clientcmd "k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ocmv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+// ocmKubeconfigSecretSuffix is the suffix the klusterlet bootstrap mirrors a
+// managed cluster's kubeconfig under, in a namespace named after the cluster.
+const ocmKubeconfigSecretSuffix = "-cluster-kubeconfig"
+
+// OCMSource discovers clusters from Open Cluster Management ManagedCluster
+// objects, reading the bootstrap/klusterlet kubeconfig Secret mirrored into
+// the cluster's own namespace (named after the ManagedCluster).
+type OCMSource struct {
+	Client client.Client
+}
+
+// NewOCMSource returns a ClusterSource backed by OCM ManagedCluster.
+func NewOCMSource(c client.Client) *OCMSource {
+	return &OCMSource{Client: c}
+}
+
+func (s *OCMSource) Name() string { return TypeOCM }
+
+func (s *OCMSource) List(ctx context.Context, selector labels.Selector) ([]DiscoveredCluster, error) {
+	list := ocmv1.ManagedClusterList{}
+	if err := s.Client.List(ctx, &list, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	discovered := make([]DiscoveredCluster, 0, len(list.Items))
+	for _, mc := range list.Items {
+		discovered = append(discovered, DiscoveredCluster{
+			Name:      mc.ObjectMeta.Name,
+			Namespace: mc.ObjectMeta.Name, // ManagedCluster is cluster-scoped; its namespace shares its name.
+			Labels:    mc.ObjectMeta.Labels,
+			Deleting:  mc.DeletionTimestamp != nil,
+			Revision:  mc.ResourceVersion,
+		})
+	}
+	return discovered, nil
+}
+
+func (s *OCMSource) KubeConfig(ctx context.Context, cluster DiscoveredCluster) (*clientcmdapi.Config, error) {
+	secret := corev1.Secret{}
+	secretReq := types.NamespacedName{Name: cluster.Name + ocmKubeconfigSecretSuffix, Namespace: cluster.Namespace}
+	if err := s.Client.Get(ctx, secretReq, &secret); err != nil {
+		return nil, err
+	}
+	kubeconfig, err := clientcmd.Load(secret.Data["kubeconfig"])
+	if err != nil {
+		return nil, fmt.Errorf("parsing kubeconfig for managedcluster %q: %w", cluster.Name, err)
+	}
+	return kubeconfig, nil
+}
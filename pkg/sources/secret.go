@@ -0,0 +1,80 @@
+/*
+Copyright 2022 Dan Molik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	clientcmd "k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// secretKubeconfigSuffix is stripped from a kubeconfig Secret's name to
+// derive the Argo CD cluster name, mirroring the `<cluster>-kubeconfig`
+// convention CAPI providers use.
+const secretKubeconfigSuffix = "-kubeconfig"
+
+// SecretSource discovers clusters from plain kubeconfig Secrets carrying a
+// label that matches the Generator's source selector, for clusters
+// provisioned outside of CAPI, Hive, or OCM.
+type SecretSource struct {
+	Client client.Client
+}
+
+// NewSecretSource returns a ClusterSource backed by labeled kubeconfig Secrets.
+func NewSecretSource(c client.Client) *SecretSource {
+	return &SecretSource{Client: c}
+}
+
+func (s *SecretSource) Name() string { return TypeSecret }
+
+func (s *SecretSource) List(ctx context.Context, selector labels.Selector) ([]DiscoveredCluster, error) {
+	list := corev1.SecretList{}
+	if err := s.Client.List(ctx, &list, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	discovered := make([]DiscoveredCluster, 0, len(list.Items))
+	for _, secret := range list.Items {
+		discovered = append(discovered, DiscoveredCluster{
+			Name:      strings.TrimSuffix(secret.ObjectMeta.Name, secretKubeconfigSuffix),
+			Namespace: secret.ObjectMeta.Namespace,
+			Labels:    secret.ObjectMeta.Labels,
+			Deleting:  secret.DeletionTimestamp != nil,
+			Revision:  secret.ResourceVersion,
+		})
+	}
+	return discovered, nil
+}
+
+func (s *SecretSource) KubeConfig(ctx context.Context, cluster DiscoveredCluster) (*clientcmdapi.Config, error) {
+	secret := corev1.Secret{}
+	secretReq := types.NamespacedName{Name: cluster.Name + secretKubeconfigSuffix, Namespace: cluster.Namespace}
+	if err := s.Client.Get(ctx, secretReq, &secret); err != nil {
+		return nil, err
+	}
+	kubeconfig, err := clientcmd.Load(secret.Data["value"])
+	if err != nil {
+		return nil, fmt.Errorf("parsing kubeconfig secret %q: %w", secret.ObjectMeta.Name, err)
+	}
+	return kubeconfig, nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2022 Dan Molik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sources discovers clusters from the various upstream APIs a
+// Generator can be configured to watch and resolves the kubeconfig that
+// authenticates to each one, so GeneratorReconciler stays agnostic to which
+// API actually produced the cluster.
+package sources
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/labels"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Source type names referenced by Generator's spec.sources[].type.
+const (
+	TypeCAPI   = "capi"
+	TypeHive   = "hive"
+	TypeOCM    = "ocm"
+	TypeSecret = "secret"
+)
+
+// DiscoveredCluster is the normalized result of a ClusterSource lookup. It
+// carries just enough information for GeneratorReconciler to drive
+// ensureSecret/deleteSecret/syncProjects without knowing which upstream API
+// produced the cluster.
+type DiscoveredCluster struct {
+	// Name identifies the cluster within the source and, by convention, the
+	// Argo CD cluster name derived from its kubeconfig's current context.
+	Name string
+	// Namespace is the namespace of the source object, or, for cluster-scoped
+	// sources such as OCM ManagedCluster, the namespace named after it.
+	Namespace string
+	// Labels are copied from the source object so a Generator's
+	// spec.sources[].selector can match against them uniformly across
+	// sources.
+	Labels map[string]string
+	// Deleting is true when the source object is being torn down and its
+	// Argo CD cluster secret should be removed.
+	Deleting bool
+	// Revision is the source object's resource version, stored on the Argo
+	// CD cluster secret for observability.
+	Revision string
+}
+
+// ClusterSource discovers clusters from a particular upstream API and knows
+// how to fetch the kubeconfig that authenticates to each one.
+type ClusterSource interface {
+	// Name identifies the source implementation, e.g. "capi", "hive".
+	Name() string
+	// List returns the clusters visible to this source that match selector.
+	List(ctx context.Context, selector labels.Selector) ([]DiscoveredCluster, error)
+	// KubeConfig returns the kubeconfig for a cluster previously returned by List.
+	KubeConfig(ctx context.Context, cluster DiscoveredCluster) (*clientcmdapi.Config, error)
+}